@@ -0,0 +1,277 @@
+// Command tmp-consul-clean sweeps known-cruft directories and files out of
+// a scratch/temp root (leftover Consul test agents, stale Go build caches,
+// gopls debug dumps, ...). See the subcommands below; run with -h on any
+// of them for its flags.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	tmpconsulclean "github.com/rboyer/tmp-consul-clean"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: tmp-consul-clean <scan|clean|report> [flags]")
+		os.Exit(1)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "scan":
+		err = cmdScan(os.Args[2:])
+	case "clean":
+		err = cmdClean(os.Args[2:])
+	case "report":
+		err = cmdReport(os.Args[2:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown subcommand %q: expected scan, clean, or report\n", os.Args[1])
+		os.Exit(1)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// commonFlags are shared by every subcommand: where to look, what rules
+// decide eligibility, how to size trees, and how to print results.
+type commonFlags struct {
+	tmpRoot    string
+	configPath string
+	estimator  string
+	printRules bool
+	format     string
+}
+
+func registerCommonFlags(fs *flag.FlagSet) *commonFlags {
+	c := &commonFlags{}
+	fs.StringVar(&c.tmpRoot, "tmp-root", "/tmp", "root of your temp directory")
+	fs.StringVar(&c.configPath, "config", "", "path to a YAML rules file (default: auto-discover ~/.config/"+tmpconsulclean.UserConfigFileName+")")
+	fs.StringVar(&c.estimator, "estimator", "native", "size estimation method to use: native or du")
+	fs.BoolVar(&c.printRules, "print-rules", false, "print the effective ruleset and exit without scanning")
+	fs.StringVar(&c.format, "format", "text", "output format: text, json, or ndjson")
+	return c
+}
+
+// resolve builds the ruleset and a Scanner from the parsed common flags.
+// If -print-rules was given, it prints the ruleset to stdout and returns
+// a nil Scanner; callers should return immediately in that case.
+func (c *commonFlags) resolve() (*tmpconsulclean.Scanner, tmpconsulclean.Format, error) {
+	format, err := tmpconsulclean.ParseFormat(c.format)
+	if err != nil {
+		return nil, "", err
+	}
+
+	rs, err := tmpconsulclean.BuildRuleSet(c.configPath)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if c.printRules {
+		rs.Print(os.Stdout)
+		return nil, format, nil
+	}
+
+	scanner := &tmpconsulclean.Scanner{
+		TmpRoot:   c.tmpRoot,
+		RuleSet:   rs,
+		Estimator: c.estimator,
+	}
+	if err := scanner.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	return scanner, format, nil
+}
+
+// cmdScan lists candidates matching the ruleset without sizing them or
+// touching the filesystem: a fast inventory.
+func cmdScan(args []string) error {
+	fs := flag.NewFlagSet("scan", flag.ExitOnError)
+	common := registerCommonFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	scanner, format, err := common.resolve()
+	if err != nil {
+		return err
+	}
+	if scanner == nil {
+		return nil // -print-rules already handled it
+	}
+
+	results, err := scanner.Scan()
+	if err != nil {
+		return fmt.Errorf("failed to scan for cruft: %w", err)
+	}
+
+	return tmpconsulclean.EmitResults(os.Stdout, format, results)
+}
+
+// reportFlags are the safety-filter flags shared by `report` and `clean`.
+type reportFlags struct {
+	minAge   time.Duration
+	maxAge   time.Duration
+	minSize  tmpconsulclean.SizeFlag
+	keepOpen bool
+}
+
+func registerReportFlags(fs *flag.FlagSet) *reportFlags {
+	r := &reportFlags{}
+	fs.DurationVar(&r.minAge, "min-age", 24*time.Hour, "skip directories whose newest file is younger than this; 0 disables")
+	fs.DurationVar(&r.maxAge, "max-age", 0, "skip directories whose newest file is older than this; 0 disables")
+	fs.Var(&r.minSize, "min-size", "skip directories smaller than this size (e.g. 10M, 512K); 0 disables")
+	fs.BoolVar(&r.keepOpen, "keep-open", false, "skip directories that contain files currently held open by another process")
+	return r
+}
+
+func (r *reportFlags) options() tmpconsulclean.FilterOptions {
+	return tmpconsulclean.FilterOptions{
+		MinAge:   r.minAge,
+		MaxAge:   r.maxAge,
+		MinSize:  int64(r.minSize),
+		KeepOpen: r.keepOpen,
+	}
+}
+
+// cmdReport scans, sizes, and applies the safety filters like `clean`
+// would, but never deletes anything — it's the scriptable, repeatable
+// inventory report meant for cron or a dashboard.
+func cmdReport(args []string) error {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	common := registerCommonFlags(fs)
+	report := registerReportFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	scanner, format, err := common.resolve()
+	if err != nil {
+		return err
+	}
+	if scanner == nil {
+		return nil
+	}
+
+	results, err := scanner.Scan()
+	if err != nil {
+		return fmt.Errorf("failed to scan for cruft: %w", err)
+	}
+	if err := scanner.EstimateSizes(results); err != nil {
+		return err
+	}
+
+	keep, skipped, err := tmpconsulclean.Filter(results, report.options())
+	if err != nil {
+		return err
+	}
+
+	var totalBytes int64
+	for _, r := range keep {
+		r.Action = "would delete"
+		totalBytes += r.SizeBytes
+	}
+
+	if err := tmpconsulclean.EmitResults(os.Stdout, format, append(keep, skipped...)); err != nil {
+		return err
+	}
+	return tmpconsulclean.EmitSummary(os.Stdout, format, tmpconsulclean.Summary{
+		TotalBytes: totalBytes,
+		Deleted:    len(keep),
+		Skipped:    len(skipped),
+	})
+}
+
+// cmdClean is the full workflow: scan, size, filter, optionally review,
+// then actually delete.
+func cmdClean(args []string) error {
+	fs := flag.NewFlagSet("clean", flag.ExitOnError)
+	common := registerCommonFlags(fs)
+	report := registerReportFlags(fs)
+	var dryRun, review bool
+	fs.BoolVar(&dryRun, "dry-run", false, "don't delete anything")
+	fs.BoolVar(&review, "review", false, "interactively review each candidate before deleting (mutually exclusive with -dry-run)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if review && dryRun {
+		return fmt.Errorf("-review and -dry-run are mutually exclusive")
+	}
+
+	scanner, format, err := common.resolve()
+	if err != nil {
+		return err
+	}
+	if scanner == nil {
+		return nil
+	}
+
+	results, err := scanner.Scan()
+	if err != nil {
+		return fmt.Errorf("failed to scan for cruft: %w", err)
+	}
+	if err := scanner.EstimateSizes(results); err != nil {
+		return err
+	}
+
+	toDelete, skipped, err := tmpconsulclean.Filter(results, report.options())
+	if err != nil {
+		return err
+	}
+
+	if review {
+		toDelete, skipped, err = tmpconsulclean.Review(toDelete, skipped, common.configPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	var totalBytes int64
+	for _, r := range toDelete {
+		if dryRun {
+			r.Action = "dry-run: would delete"
+		} else {
+			if err := os.RemoveAll(r.Path); err != nil {
+				if tmpconsulclean.IsPermDenied(err) {
+					r.Action = fmt.Sprintf("skip: %v", err)
+					r.Error = err.Error()
+					skipped = append(skipped, r)
+					continue
+				}
+				return fmt.Errorf("failed to delete cruft %s: %w", r.Path, err)
+			}
+			r.Action = "deleted"
+		}
+		totalBytes += r.SizeBytes
+	}
+
+	if format == tmpconsulclean.FormatText {
+		for _, r := range toDelete {
+			fmt.Fprintf(os.Stdout, "INFO: %s: %s\n", r.Action, r.Path)
+		}
+		for _, r := range skipped {
+			fmt.Fprintf(os.Stdout, "INFO: %s: %s\n", r.Action, r.Path)
+		}
+	} else if err := tmpconsulclean.EmitResults(os.Stdout, format, append(toDelete, skipped...)); err != nil {
+		return err
+	}
+
+	deletedCount := 0
+	for _, r := range toDelete {
+		if r.Action == "deleted" || r.Action == "dry-run: would delete" {
+			deletedCount++
+		}
+	}
+
+	return tmpconsulclean.EmitSummary(os.Stdout, format, tmpconsulclean.Summary{
+		TotalBytes: totalBytes,
+		Deleted:    deletedCount,
+		Skipped:    len(skipped),
+	})
+}
@@ -0,0 +1,82 @@
+package tmpconsulclean
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Format selects how Results and a Summary are rendered for scripting.
+type Format string
+
+const (
+	FormatText   Format = "text"
+	FormatJSON   Format = "json"
+	FormatNDJSON Format = "ndjson"
+)
+
+// ParseFormat validates a -format flag value.
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case FormatText, FormatJSON, FormatNDJSON:
+		return Format(s), nil
+	default:
+		return "", fmt.Errorf("invalid -format %q: must be 'text', 'json', or 'ndjson'", s)
+	}
+}
+
+// Summary totals a run for the final report, in addition to the
+// per-candidate Results.
+type Summary struct {
+	TotalBytes int64 `json:"total_bytes"`
+	Deleted    int   `json:"deleted"`
+	Skipped    int   `json:"skipped"`
+}
+
+// EmitResults writes one record per result in the requested format. In
+// FormatText it's a human-readable line per result; in FormatJSON the
+// whole slice is written as a single JSON array; in FormatNDJSON each
+// result is its own JSON object on its own line, suitable for piping into
+// jq or a log aggregator.
+func EmitResults(w io.Writer, format Format, results []*Result) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	case FormatNDJSON:
+		enc := json.NewEncoder(w)
+		for _, r := range results {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		for _, r := range results {
+			action := r.Action
+			if action == "" {
+				action = "scan"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", action, r.Path, humanizeBytes(r.SizeBytes), r.MatchedRule)
+		}
+		return nil
+	}
+}
+
+// EmitSummary writes the final summary object/line in the requested
+// format.
+func EmitSummary(w io.Writer, format Format, summary Summary) error {
+	switch format {
+	case FormatJSON, FormatNDJSON:
+		enc := json.NewEncoder(w)
+		if format == FormatJSON {
+			enc.SetIndent("", "  ")
+		}
+		return enc.Encode(summary)
+	default:
+		fmt.Fprintf(w, "estimated savings ~%s from %d directories (%d skipped)\n",
+			humanizeBytes(summary.TotalBytes), summary.Deleted, summary.Skipped)
+		return nil
+	}
+}
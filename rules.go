@@ -0,0 +1,283 @@
+// Package tmpconsulclean implements the scanning, sizing, filtering, and
+// rule-matching logic behind the tmp-consul-clean CLI (cmd/tmp-consul-clean),
+// so it can also be driven as a library from other Go programs.
+package tmpconsulclean
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"gopkg.in/yaml.v3"
+)
+
+// UserConfigFileName is the name looked for under $HOME/.config when no
+// explicit config path is given.
+const UserConfigFileName = "tmp-consul-clean.yml"
+
+// Rule is one gitignore-style pattern entry. Patterns are matched against
+// a bare top-level file/directory name with doublestar glob semantics
+// (supporting "**", though since we only ever match a single path segment
+// it behaves like a plain glob in practice).
+type Rule struct {
+	Pattern string // glob pattern, negation and trailing "/" already stripped
+	Negate  bool   // pattern was prefixed with "!"
+	DirOnly bool   // pattern had a trailing "/"
+	Source  string // "built-in" or the config file path it came from, for -print-rules
+}
+
+// Match reports whether name satisfies the rule's glob pattern.
+func (r Rule) Match(name string) (bool, error) {
+	return doublestar.Match(r.Pattern, name)
+}
+
+func (r Rule) String() string {
+	s := r.Pattern
+	if r.DirOnly {
+		s += "/"
+	}
+	if r.Negate {
+		s = "!" + s
+	}
+	return s
+}
+
+// RuleSet is the compiled, effective policy: built-in defaults followed by
+// user rules, evaluated in order so that later entries win ties and a "!"
+// entry can re-include something an earlier pattern excluded.
+type RuleSet struct {
+	DirRules  []Rule
+	FileRules []Rule
+}
+
+// Matches reports whether name (a child of the scan root) should be
+// deleted, using last-match-wins, gitignore-style evaluation.
+func (rs *RuleSet) Matches(dir bool, name string) (bool, error) {
+	matched, _, err := rs.MatchRule(dir, name)
+	return matched, err
+}
+
+// MatchRule is like Matches but also returns the rule responsible for the
+// final verdict, so callers (e.g. -review or -print-rules) can show the
+// user why a candidate was picked.
+func (rs *RuleSet) MatchRule(dir bool, name string) (bool, Rule, error) {
+	rules := rs.FileRules
+	if dir {
+		rules = rs.DirRules
+	}
+
+	var matched bool
+	var matchedRule Rule
+	for _, r := range rules {
+		if r.DirOnly && !dir {
+			continue
+		}
+		ok, err := r.Match(name)
+		if err != nil {
+			return false, Rule{}, fmt.Errorf("bad pattern %q: %w", r.Pattern, err)
+		}
+		if ok {
+			matched = !r.Negate
+			matchedRule = r
+		}
+	}
+	return matched, matchedRule, nil
+}
+
+// Print writes the effective ruleset, one pattern per line, annotated with
+// where it came from.
+func (rs *RuleSet) Print(w io.Writer) {
+	fmt.Fprintln(w, "dirs:")
+	for _, r := range rs.DirRules {
+		fmt.Fprintf(w, "  %s\t(%s)\n", r, r.Source)
+	}
+	fmt.Fprintln(w, "files:")
+	for _, r := range rs.FileRules {
+		fmt.Fprintf(w, "  %s\t(%s)\n", r, r.Source)
+	}
+}
+
+// BuiltinRuleSet mirrors the hardcoded prefixes this tool originally
+// shipped with, just re-expressed as glob patterns.
+func BuiltinRuleSet() *RuleSet {
+	mk := func(dirOnly bool, patterns ...string) []Rule {
+		rules := make([]Rule, 0, len(patterns))
+		for _, p := range patterns {
+			rules = append(rules, Rule{Pattern: p, DirOnly: dirOnly, Source: "built-in"})
+		}
+		return rules
+	}
+
+	return &RuleSet{
+		DirRules: mk(true,
+			"consul-test", // definitely nuke the weird toplevel
+			"007-agent*",
+			"agent_smith*",
+			"go-build*",
+			"jones-agent*",
+			"Test*",
+			"test-agent*",
+			"test-consul-agent*",
+			"consul*",
+			"Agent1-agent*",
+			"Agent2-agent*",
+			"betty-agent*",
+			"bob-agent*",
+			"bonnie-agent*",
+			"dc1-agent*",
+			"dc2-agent*",
+			"gopls-*",
+			// "vim-go*",
+			"dc1-consul*",
+			"dc2-consul*",
+			"test-container*",
+		),
+		FileRules: mk(false,
+			"snapshot*",
+			"config-err-*",
+			"go.*.sum",
+			"go.*.mod",
+			"gopls.*-heap.pb.gz",
+			"gopls.*-goroutines.txt",
+			"gopls-*.log",
+			"gopls.*.zip",
+		),
+	}
+}
+
+// UserConfig is the on-disk shape of a -config YAML file: separate dirs:
+// and files: sections, each a list of gitignore-style patterns.
+type UserConfig struct {
+	Dirs  []string `yaml:"dirs"`
+	Files []string `yaml:"files"`
+}
+
+func loadUserConfig(path string) (*UserConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg UserConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// DefaultUserConfigPath returns the auto-discovered ~/.config/tmp-consul-clean.yml
+// path, or "" if it doesn't exist or $HOME can't be determined.
+func DefaultUserConfigPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	path := filepath.Join(home, ".config", UserConfigFileName)
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+func parseConfigRules(patterns []string, dirOnlyDefault bool, source string) []Rule {
+	rules := make([]Rule, 0, len(patterns))
+	for _, p := range patterns {
+		r := Rule{Source: source}
+		if strings.HasPrefix(p, "!") {
+			r.Negate = true
+			p = p[1:]
+		}
+		if strings.HasSuffix(p, "/") {
+			r.DirOnly = true
+			p = strings.TrimSuffix(p, "/")
+		} else {
+			r.DirOnly = dirOnlyDefault
+		}
+		r.Pattern = p
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+// BuildRuleSet merges the built-in defaults with the rules found in
+// configPath (explicit -config flag, falling back to the auto-discovered
+// user config when configPath is ""). User rules are appended after the
+// built-ins, so a negation in the user config can win against a built-in
+// default.
+func BuildRuleSet(configPath string) (*RuleSet, error) {
+	rs := BuiltinRuleSet()
+
+	if configPath == "" {
+		configPath = DefaultUserConfigPath()
+	}
+	if configPath == "" {
+		return rs, nil
+	}
+
+	cfg, err := loadUserConfig(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not load -config %s: %w", configPath, err)
+	}
+
+	rs.DirRules = append(rs.DirRules, parseConfigRules(cfg.Dirs, true, configPath)...)
+	rs.FileRules = append(rs.FileRules, parseConfigRules(cfg.Files, false, configPath)...)
+
+	return rs, nil
+}
+
+// UserConfigSavePath returns where -review's "always keep" choices should
+// be written: configPath if one was given, otherwise the auto-discovered
+// location (created if it doesn't exist yet).
+func UserConfigSavePath(configPath string) (string, error) {
+	if configPath != "" {
+		return configPath, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not determine a user config path to save to: %w", err)
+	}
+	return filepath.Join(home, ".config", UserConfigFileName), nil
+}
+
+// AppendAlwaysKeepRules loads the user config at configPath (if any),
+// appends the given negation patterns to its dirs/files sections, and
+// writes it back out so the tool "learns" -review's always-keep choices
+// for next time.
+func AppendAlwaysKeepRules(configPath string, dirPatterns, filePatterns []string) (string, error) {
+	if len(dirPatterns) == 0 && len(filePatterns) == 0 {
+		return "", nil
+	}
+
+	path, err := UserConfigSavePath(configPath)
+	if err != nil {
+		return "", err
+	}
+
+	var cfg UserConfig
+	if existing, err := loadUserConfig(path); err == nil {
+		cfg = *existing
+	} else if !os.IsNotExist(err) {
+		return "", fmt.Errorf("could not load existing %s: %w", path, err)
+	}
+
+	cfg.Dirs = append(cfg.Dirs, dirPatterns...)
+	cfg.Files = append(cfg.Files, filePatterns...)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("could not create config directory for %s: %w", path, err)
+	}
+
+	out, err := yaml.Marshal(&cfg)
+	if err != nil {
+		return "", fmt.Errorf("could not serialize %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, out, 0o644); err != nil {
+		return "", fmt.Errorf("could not write %s: %w", path, err)
+	}
+
+	return path, nil
+}
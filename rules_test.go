@@ -0,0 +1,43 @@
+package tmpconsulclean
+
+import "testing"
+
+func TestRuleSetMatchRuleNegationWins(t *testing.T) {
+	rs := BuiltinRuleSet()
+	rs.DirRules = append(rs.DirRules, parseConfigRules([]string{"!go-build*"}, true, "test-config")...)
+
+	matched, rule, err := rs.MatchRule(true, "go-build1234567890")
+	if err != nil {
+		t.Fatalf("MatchRule returned error: %v", err)
+	}
+	if matched {
+		t.Fatalf("expected the later negation to override the built-in go-build* rule, got matched=%v rule=%v", matched, rule)
+	}
+	if !rule.Negate {
+		t.Fatalf("expected the winning rule to be the negation, got %v", rule)
+	}
+}
+
+func TestRuleSetMatchRuleBuiltinStillMatchesWithoutNegation(t *testing.T) {
+	rs := BuiltinRuleSet()
+
+	matched, _, err := rs.MatchRule(true, "go-build1234567890")
+	if err != nil {
+		t.Fatalf("MatchRule returned error: %v", err)
+	}
+	if !matched {
+		t.Fatal("expected the built-in go-build* rule to match without a user override")
+	}
+}
+
+func TestRuleSetMatchRuleNoMatch(t *testing.T) {
+	rs := BuiltinRuleSet()
+
+	matched, _, err := rs.MatchRule(true, "some-unrelated-dir")
+	if err != nil {
+		t.Fatalf("MatchRule returned error: %v", err)
+	}
+	if matched {
+		t.Fatal("expected an unrelated directory name not to match any built-in rule")
+	}
+}
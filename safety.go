@@ -0,0 +1,202 @@
+package tmpconsulclean
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FilterOptions configures the age/size/keep-open safety filters applied
+// between scanning and deletion.
+type FilterOptions struct {
+	MinAge   time.Duration // skip anything whose newest mtime is younger than this; 0 disables
+	MaxAge   time.Duration // skip anything whose newest mtime is older than this; 0 disables
+	MinSize  int64         // skip anything smaller than this many bytes; 0 disables
+	KeepOpen bool          // skip anything with files currently held open by another process
+}
+
+// Filter splits results into what's still safe to delete and what a
+// safety filter skipped, stamping Result.Action with the reason on the
+// skipped ones so it round-trips through -format=json. A result with an
+// unknown (zero) NewestMTime fails closed against -min-age: it's treated
+// as too new to touch rather than exempt from the check.
+func Filter(results []*Result, opts FilterOptions) (keep []*Result, skipped []*Result, err error) {
+	now := time.Now()
+
+	for _, r := range results {
+		if opts.MinAge > 0 && r.NewestMTime.IsZero() {
+			r.Action = "skip: age unknown, treating as too new to touch"
+			skipped = append(skipped, r)
+			continue
+		}
+
+		if opts.MinAge > 0 && !r.NewestMTime.IsZero() {
+			if age := now.Sub(r.NewestMTime); age < opts.MinAge {
+				r.Action = fmt.Sprintf("skip: modified %s ago, newer than min-age=%s", age.Round(time.Second), opts.MinAge)
+				skipped = append(skipped, r)
+				continue
+			}
+		}
+
+		if opts.MaxAge > 0 && !r.NewestMTime.IsZero() {
+			if age := now.Sub(r.NewestMTime); age > opts.MaxAge {
+				r.Action = fmt.Sprintf("skip: modified %s ago, older than max-age=%s", age.Round(time.Second), opts.MaxAge)
+				skipped = append(skipped, r)
+				continue
+			}
+		}
+
+		if opts.MinSize > 0 && r.SizeBytes < opts.MinSize {
+			r.Action = fmt.Sprintf("skip: size %s is below min-size=%s", humanizeBytes(r.SizeBytes), humanizeBytes(opts.MinSize))
+			skipped = append(skipped, r)
+			continue
+		}
+
+		if opts.KeepOpen {
+			open, err := dirHasOpenFiles(r.Path)
+			if err != nil {
+				logWarn("could not check open files for %s: %v", r.Path, err)
+			} else if open {
+				r.Action = "skip: has files currently open by another process"
+				skipped = append(skipped, r)
+				continue
+			}
+		}
+
+		keep = append(keep, r)
+	}
+
+	return keep, skipped, nil
+}
+
+// SizeFlag implements flag.Value so -min-size accepts human sizes like
+// "10M" or "512K" in addition to a bare byte count.
+type SizeFlag int64
+
+func (s *SizeFlag) String() string {
+	if s == nil {
+		return "0"
+	}
+	return humanizeBytes(int64(*s))
+}
+
+func (s *SizeFlag) Set(raw string) error {
+	v, err := ParseByteSize(raw)
+	if err != nil {
+		return err
+	}
+	*s = SizeFlag(v)
+	return nil
+}
+
+var byteSizeSuffixes = []struct {
+	suffix string
+	mult   int64
+}{
+	{"TB", 1 << 40}, {"T", 1 << 40},
+	{"GB", 1 << 30}, {"G", 1 << 30},
+	{"MB", 1 << 20}, {"M", 1 << 20},
+	{"KB", 1 << 10}, {"K", 1 << 10},
+	{"B", 1},
+}
+
+// ParseByteSize parses a human size like "10M", "512K", or "1.5G" (binary,
+// base-1024 units), or a bare integer byte count.
+func ParseByteSize(raw string) (int64, error) {
+	s := strings.TrimSpace(raw)
+	if s == "" {
+		return 0, fmt.Errorf("empty size")
+	}
+
+	upper := strings.ToUpper(s)
+	for _, suf := range byteSizeSuffixes {
+		if !strings.HasSuffix(upper, suf.suffix) {
+			continue
+		}
+		numPart := strings.TrimSpace(s[:len(s)-len(suf.suffix)])
+		v, err := strconv.ParseFloat(numPart, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid size %q: %w", raw, err)
+		}
+		return int64(v * float64(suf.mult)), nil
+	}
+
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: %w", raw, err)
+	}
+	return v, nil
+}
+
+// dirHasOpenFiles reports whether any file under dir is currently held
+// open by a process other than us, so a running test or build isn't
+// deleted out from under it.
+func dirHasOpenFiles(dir string) (bool, error) {
+	if runtime.GOOS == "linux" {
+		return dirHasOpenFilesProc(dir)
+	}
+	return dirHasOpenFilesLsof(dir)
+}
+
+// dirHasOpenFilesProc scans /proc/*/fd for symlinks resolving into dir.
+// Processes we don't own show up as permission-denied reading their fd
+// directory; those are skipped rather than treated as an error, since
+// there's nothing actionable we can do about another user's process.
+func dirHasOpenFilesProc(dir string) (bool, error) {
+	procEntries, err := os.ReadDir("/proc")
+	if err != nil {
+		return false, fmt.Errorf("could not read /proc: %w", err)
+	}
+
+	cleanDir := filepath.Clean(dir)
+	prefix := cleanDir + string(filepath.Separator)
+
+	for _, pe := range procEntries {
+		if _, err := strconv.Atoi(pe.Name()); err != nil {
+			continue // not a pid directory
+		}
+
+		fdDir := filepath.Join("/proc", pe.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue // process exited or its fds aren't ours to read
+		}
+
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil {
+				continue
+			}
+			if target == cleanDir || strings.HasPrefix(target, prefix) {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// dirHasOpenFilesLsof is the non-Linux fallback (macOS, BSD, ...) where
+// /proc isn't available.
+func dirHasOpenFilesLsof(dir string) (bool, error) {
+	cmd := exec.Command("lsof", "+D", dir)
+
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	err := cmd.Run()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			return false, nil // lsof: no open files found under dir
+		}
+		return false, fmt.Errorf("lsof failed: %w", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	return len(lines) > 1, nil // first line is just the column header
+}
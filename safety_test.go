@@ -0,0 +1,71 @@
+package tmpconsulclean
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFilterMinAge(t *testing.T) {
+	opts := FilterOptions{MinAge: 24 * time.Hour}
+
+	tooNew := &Result{Path: "/tmp/go-build1", NewestMTime: time.Now().Add(-1 * time.Hour)}
+	oldEnough := &Result{Path: "/tmp/go-build2", NewestMTime: time.Now().Add(-48 * time.Hour)}
+	unknown := &Result{Path: "/tmp/go-build3"}
+
+	keep, skipped, err := Filter([]*Result{tooNew, oldEnough, unknown}, opts)
+	if err != nil {
+		t.Fatalf("Filter returned error: %v", err)
+	}
+
+	if len(keep) != 1 || keep[0] != oldEnough {
+		t.Fatalf("expected only oldEnough to be kept, got %v", keep)
+	}
+	if len(skipped) != 2 {
+		t.Fatalf("expected tooNew and unknown to be skipped, got %v", skipped)
+	}
+}
+
+func TestFilterUnknownAgeFailsClosed(t *testing.T) {
+	opts := FilterOptions{MinAge: 24 * time.Hour}
+	unknown := &Result{Path: "/tmp/go-build1"}
+
+	keep, skipped, err := Filter([]*Result{unknown}, opts)
+	if err != nil {
+		t.Fatalf("Filter returned error: %v", err)
+	}
+	if len(keep) != 0 {
+		t.Fatalf("expected an unknown-age result to be skipped, not kept: %v", keep)
+	}
+	if len(skipped) != 1 {
+		t.Fatalf("expected an unknown-age result to show up in skipped, got %v", skipped)
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	cases := []struct {
+		raw  string
+		want int64
+	}{
+		{"10M", 10 << 20},
+		{"512K", 512 << 10},
+		{"1234", 1234},
+		{"2G", 2 << 30},
+	}
+
+	for _, c := range cases {
+		got, err := ParseByteSize(c.raw)
+		if err != nil {
+			t.Errorf("ParseByteSize(%q) returned error: %v", c.raw, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("ParseByteSize(%q) = %d, want %d", c.raw, got, c.want)
+		}
+	}
+}
+
+func TestParseByteSizeInvalid(t *testing.T) {
+	if _, err := ParseByteSize("bogus"); err == nil {
+		t.Fatal("expected an error for an unparseable size")
+	}
+}
@@ -0,0 +1,254 @@
+package tmpconsulclean
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// maxParallelEstimators bounds how many directory trees are sized
+// concurrently, so we don't fork a goroutine (and pile of open file
+// descriptors) per top-level directory on a tmp root with hundreds of
+// entries.
+const maxParallelEstimators = 8
+
+// Result is one scanned candidate: a file or directory under a Scanner's
+// TmpRoot that matched the ruleset, along with whatever the scanner has
+// learned about it so far (size, newest mtime) and what ultimately
+// happened to it (Action, Error). This is also the unit emitted by
+// -format=json/ndjson.
+type Result struct {
+	Path        string    `json:"path"`
+	IsDir       bool      `json:"is_dir"`
+	MatchedRule string    `json:"matched_rule"`
+	SizeBytes   int64     `json:"size_bytes"`
+	NewestMTime time.Time `json:"newest_mtime,omitempty"`
+	Action      string    `json:"action"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// Scanner holds the configuration needed to discover and size cruft under
+// a temp root: where to look, which rules decide what's eligible, and how
+// to estimate tree sizes.
+type Scanner struct {
+	TmpRoot   string
+	RuleSet   *RuleSet
+	Estimator string // "" or "native" (default), or "du"
+}
+
+// Validate checks that the Scanner's fields hold recognized values before
+// it's used, catching e.g. a typo'd -estimator flag instead of silently
+// falling back to the native walker.
+func (s *Scanner) Validate() error {
+	switch s.Estimator {
+	case "", "native", "du":
+		return nil
+	default:
+		return fmt.Errorf("invalid estimator %q: must be 'native' or 'du'", s.Estimator)
+	}
+}
+
+// Scan lists the entries directly under s.TmpRoot that match s.RuleSet,
+// without sizing them. Use EstimateSizes to fill in SizeBytes/NewestMTime
+// afterwards.
+func (s *Scanner) Scan() ([]*Result, error) {
+	entries, err := os.ReadDir(s.TmpRoot)
+	if err != nil {
+		return nil, fmt.Errorf("could not list contents of %s: %w", s.TmpRoot, err)
+	}
+
+	var results []*Result
+	for _, st := range entries {
+		del, r, err := s.RuleSet.MatchRule(st.IsDir(), st.Name())
+		if err != nil {
+			return nil, err
+		}
+		if del {
+			results = append(results, &Result{
+				Path:        filepath.Join(s.TmpRoot, st.Name()),
+				IsDir:       st.IsDir(),
+				MatchedRule: r.String(),
+			})
+		}
+	}
+
+	return results, nil
+}
+
+// EstimateSizes fans out estimateTreeSize across results, bounded by
+// maxParallelEstimators, filling in each result's SizeBytes/NewestMTime in
+// place. A candidate that fails estimation (permission error, or one that
+// disappeared mid-scan) is logged, recorded on Result.Error, and left with
+// whatever partial stats were gathered, rather than aborting the whole
+// batch: a single raced-away or unreadable directory shouldn't take down
+// an entire scan/report/clean invocation.
+func (s *Scanner) EstimateSizes(results []*Result) error {
+	var g errgroup.Group
+	g.SetLimit(maxParallelEstimators)
+
+	for _, r := range results {
+		r := r
+		g.Go(func() error {
+			bytes, newest, err := s.estimateTreeSize(r.Path)
+			if err != nil {
+				logWarn("skipping %s for estimation: %v", r.Path, err)
+				r.Error = err.Error()
+			}
+
+			r.SizeBytes = bytes
+			r.NewestMTime = newest
+			return nil
+		})
+	}
+
+	return g.Wait()
+}
+
+func (s *Scanner) estimateTreeSize(d string) (int64, time.Time, error) {
+	if d == "" {
+		return 0, time.Time{}, fmt.Errorf("missing directory name")
+	}
+
+	switch s.Estimator {
+	case "du":
+		return estimateTreeSizeDu(d)
+	default:
+		return estimateTreeSizeNative(d)
+	}
+}
+
+// estimateTreeSizeNative walks d using filepath.WalkDir, summing apparent
+// file sizes from the fs.DirEntry's Info() rather than issuing a separate
+// Stat per entry, and tracking the newest ModTime seen anywhere in the
+// tree. Any per-entry walk error is tolerated: the offending file or
+// subtree is skipped (logged, not just a permission error, since a live
+// /tmp can also race a path out from under the walk via ENOENT) and
+// walking continues against the rest of the tree, rather than aborting
+// the whole estimate.
+func estimateTreeSizeNative(d string) (int64, time.Time, error) {
+	var total int64
+	var newest time.Time
+
+	err := filepath.WalkDir(d, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			logWarn("skipping %s during size estimation: %v", path, err)
+			if entry != nil && entry.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			logWarn("skipping %s during size estimation: %v", path, err)
+			return nil
+		}
+
+		if info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+		if !entry.IsDir() {
+			total += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return total, newest, err
+	}
+
+	return total, newest, nil
+}
+
+var duRE = regexp.MustCompile(`^([0-9]+)\s+`)
+
+// estimateTreeSizeDu shells out to du for an A/B comparison against the
+// native walker. Since du doesn't report a deepest-mtime, it falls back to
+// the top-level directory's own mtime, which is less precise than the
+// native walker's. That mtime comes from the os.Stat below rather than du
+// itself, so it's still returned even on a du failure: callers (notably
+// Filter's age checks) need a real timestamp rather than a zero Time to
+// treat the candidate as safe to consider.
+func estimateTreeSizeDu(d string) (int64, time.Time, error) {
+	fi, err := os.Stat(d)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	cmd := exec.Command("du", "-s", "--block-size=1", d)
+
+	var stderr bytes.Buffer
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return 0, fi.ModTime(), fmt.Errorf("%v: %s", err, stderr.String())
+	}
+
+	s := stdout.String()
+	m := duRE.FindStringSubmatch(s)
+	if m == nil || len(m) != 2 {
+		return 0, fi.ModTime(), fmt.Errorf("unrecognized du output: %s", s)
+	}
+
+	v, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return 0, fi.ModTime(), fmt.Errorf("unrecognized du output: %s", s)
+	}
+
+	return v, fi.ModTime(), nil
+}
+
+// IsPermDenied reports whether err looks like a permission-denied error,
+// the one failure mode scanning/sizing/deletion treat as skip-and-warn
+// rather than fatal.
+func IsPermDenied(err error) bool {
+	return err != nil && strings.Contains(strings.ToLower(err.Error()), "permission denied")
+}
+
+func humanizeBytes(v int64) string {
+	unitF := func(v int64, s string) string {
+		return strconv.FormatInt(v, 10) + "" + s
+	}
+	if v < 1024 {
+		return unitF(v, "B")
+	}
+
+	v /= 1024
+	if v < 1024 {
+		return unitF(v, "K")
+	}
+
+	v /= 1024
+	if v < 1024 {
+		return unitF(v, "M")
+	}
+
+	v /= 1024
+	if v < 1024 {
+		return unitF(v, "G")
+	}
+
+	v /= 1024
+	return unitF(v, "T")
+}
+
+func logErr(format string, a ...any) {
+	fmt.Fprintf(os.Stderr, "ERROR: "+format+"\n", a...)
+}
+
+func logWarn(format string, a ...any) {
+	fmt.Fprintf(os.Stderr, "WARN: "+format+"\n", a...)
+}
+
+func logInfo(format string, a ...any) {
+	fmt.Fprintf(os.Stdout, "INFO: "+format+"\n", a...)
+}
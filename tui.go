@@ -0,0 +1,134 @@
+package tmpconsulclean
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// ErrReviewUnsupported is returned by Review when stdin isn't an
+// interactive terminal.
+var ErrReviewUnsupported = errors.New("-review requires an interactive terminal")
+
+// Review walks the user through each result one at a time in a minimal
+// raw-terminal UI, returning the ones still slated for deletion plus an
+// updated skipped list (appending to the skipped passed in). A
+// "keep-always" choice is recorded as a negation rule in the config file
+// at configPath (or the auto-discovered one) so the tool stops flagging
+// that path on future runs.
+func Review(results []*Result, skipped []*Result, configPath string) (toDelete []*Result, allSkipped []*Result, err error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, nil, ErrReviewUnsupported
+	}
+
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not enter raw terminal mode for -review: %w", err)
+	}
+	defer term.Restore(fd, oldState)
+
+	in := bufio.NewReader(os.Stdin)
+
+	var alwaysKeepDirs, alwaysKeepFiles []string
+
+	for i, r := range results {
+		age := "unknown"
+		if !r.NewestMTime.IsZero() {
+			age = time.Since(r.NewestMTime).Round(time.Second).String() + " old"
+		}
+		fmt.Fprintf(os.Stdout, "\r\n[%d/%d] %s\r\n", i+1, len(results), r.Path)
+		fmt.Fprintf(os.Stdout, "  size=%s  age=%s  rule=%s\r\n", humanizeBytes(r.SizeBytes), age, r.MatchedRule)
+		fmt.Fprint(os.Stdout, "  [d]elete  [k]eep once  [a]lways keep  [q]uit review> \r\n")
+
+		choice, err := readReviewChoice(in)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		switch choice {
+		case 'd':
+			toDelete = append(toDelete, r)
+		case 'k':
+			r.Action = "skip: kept once during -review"
+			skipped = append(skipped, r)
+		case 'a':
+			r.Action = "skip: marked always-keep during -review"
+			skipped = append(skipped, r)
+			pattern := negatedRulePattern(r.MatchedRule)
+			if r.IsDir {
+				alwaysKeepDirs = append(alwaysKeepDirs, pattern)
+			} else {
+				alwaysKeepFiles = append(alwaysKeepFiles, pattern)
+			}
+		case 'q':
+			for _, rest := range results[i:] {
+				rest.Action = "skip: review aborted by user"
+				skipped = append(skipped, rest)
+			}
+			if err := saveAlwaysKeep(configPath, alwaysKeepDirs, alwaysKeepFiles); err != nil {
+				return nil, nil, err
+			}
+			return toDelete, skipped, nil
+		}
+	}
+
+	if err := saveAlwaysKeep(configPath, alwaysKeepDirs, alwaysKeepFiles); err != nil {
+		return nil, nil, err
+	}
+
+	return toDelete, skipped, nil
+}
+
+// negatedRulePattern turns the rule that matched a candidate (e.g.
+// "go-build*/") into a negation entry for the user config (e.g.
+// "!go-build*/"). Using the matched rule's own glob, rather than the
+// candidate's literal basename, means the negation still matches on
+// future runs even when the built-in rule matches randomly-suffixed
+// directories like "go-build1234567890".
+func negatedRulePattern(matchedRule string) string {
+	s := strings.TrimPrefix(matchedRule, "!")
+	dirOnly := strings.HasSuffix(s, "/")
+	s = strings.TrimSuffix(s, "/")
+	if dirOnly {
+		return "!" + s + "/"
+	}
+	return "!" + s
+}
+
+func saveAlwaysKeep(configPath string, dirPatterns, filePatterns []string) error {
+	path, err := AppendAlwaysKeepRules(configPath, dirPatterns, filePatterns)
+	if err != nil {
+		return err
+	}
+	if path != "" {
+		logInfo("saved %d always-keep rule(s) to %s", len(dirPatterns)+len(filePatterns), path)
+	}
+	return nil
+}
+
+// readReviewChoice blocks for a single d/k/a/q keypress (case-insensitive),
+// ignoring anything else.
+func readReviewChoice(in *bufio.Reader) (byte, error) {
+	for {
+		b, err := in.ReadByte()
+		if err != nil {
+			return 0, fmt.Errorf("reading -review input: %w", err)
+		}
+		switch b {
+		case 'd', 'D':
+			return 'd', nil
+		case 'k', 'K':
+			return 'k', nil
+		case 'a', 'A':
+			return 'a', nil
+		case 'q', 'Q', 3: // 3 == Ctrl-C
+			return 'q', nil
+		}
+	}
+}